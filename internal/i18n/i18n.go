@@ -0,0 +1,94 @@
+// Package i18n loads per-locale message catalogs and resolves the right one for a request,
+// so API error messages can be translated instead of hardcoded English strings.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// DefaultLocale is used when the requested locale has no catalog or a message id is missing from it
+const DefaultLocale = "en"
+
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[string]map[string]string {
+	entries, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		log.Error("i18n: unable to read locales: ", err)
+		return map[string]map[string]string{}
+	}
+
+	loaded := map[string]map[string]string{}
+	for _, entry := range entries {
+		locale := strings.TrimSuffix(entry.Name(), ".json")
+
+		data, err := localeFiles.ReadFile("locales/" + entry.Name())
+		if err != nil {
+			log.Errorf("i18n: unable to read locale %s: %v", locale, err)
+			continue
+		}
+
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			log.Errorf("i18n: unable to parse locale %s: %v", locale, err)
+			continue
+		}
+
+		loaded[locale] = messages
+	}
+	return loaded
+}
+
+// SupportedLocales returns the locales with a loaded catalog
+func SupportedLocales() []string {
+	locales := make([]string, 0, len(catalogs))
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	return locales
+}
+
+// Localizer resolves message ids to a specific locale, falling back to DefaultLocale
+type Localizer struct {
+	locale string
+}
+
+// NewLocalizer returns a Localizer for locale, falling back to DefaultLocale if unknown
+func NewLocalizer(locale string) *Localizer {
+	if _, ok := catalogs[locale]; !ok {
+		locale = DefaultLocale
+	}
+	return &Localizer{locale: locale}
+}
+
+// Locale returns the resolved locale, e.g. for surfacing to the client
+func (l *Localizer) Locale() string {
+	return l.locale
+}
+
+// T looks up id in the resolved locale, falling back to DefaultLocale, then to id itself.
+// Extra args are applied with fmt.Sprintf against the message's format verbs.
+func (l *Localizer) T(id string, args ...interface{}) string {
+	if msg, ok := catalogs[l.locale][id]; ok {
+		return format(msg, args)
+	}
+	if msg, ok := catalogs[DefaultLocale][id]; ok {
+		return format(msg, args)
+	}
+	return id
+}
+
+func format(msg string, args []interface{}) string {
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
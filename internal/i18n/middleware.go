@@ -0,0 +1,91 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const contextKey = "i18n.localizer"
+
+// Middleware resolves the request's locale (query override, then Accept-Language, then
+// DefaultLocale) and stores a *Localizer on the gin context for handlers to use
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(contextKey, NewLocalizer(resolveLocale(c)))
+		c.Next()
+	}
+}
+
+// FromContext returns the request's Localizer, or one for DefaultLocale if Middleware wasn't run
+func FromContext(c *gin.Context) *Localizer {
+	if v, ok := c.Get(contextKey); ok {
+		if localizer, ok := v.(*Localizer); ok {
+			return localizer
+		}
+	}
+	return NewLocalizer(DefaultLocale)
+}
+
+func resolveLocale(c *gin.Context) string {
+	if lang := c.Query("lang"); lang != "" {
+		if _, ok := catalogs[lang]; ok {
+			return lang
+		}
+	}
+	for _, tag := range parseAcceptLanguage(c.GetHeader("Accept-Language")) {
+		if _, ok := catalogs[tag]; ok {
+			return tag
+		}
+	}
+	return DefaultLocale
+}
+
+type weightedTag struct {
+	tag    string
+	weight float64
+}
+
+// parseAcceptLanguage returns base language tags (e.g. "en-US" -> "en"), sorted by descending
+// q-weight, so callers can walk it and take the first tag with a catalog
+func parseAcceptLanguage(header string) []string {
+	if header == "" {
+		return nil
+	}
+
+	var tags []weightedTag
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		tag, weight := part, 1.0
+		if idx := strings.Index(part, ";q="); idx != -1 {
+			tag = part[:idx]
+			if parsed, err := strconv.ParseFloat(part[idx+3:], 64); err == nil {
+				weight = parsed
+			}
+		}
+
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if idx := strings.IndexAny(tag, "-_"); idx != -1 {
+			tag = tag[:idx]
+		}
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		tags = append(tags, weightedTag{tag: tag, weight: weight})
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].weight > tags[j].weight })
+
+	result := make([]string, len(tags))
+	for i, t := range tags {
+		result[i] = t.tag
+	}
+	return result
+}
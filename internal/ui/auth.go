@@ -0,0 +1,183 @@
+package ui
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ddvk/rmfakecloud/internal/config"
+	"github.com/ddvk/rmfakecloud/internal/db"
+	"github.com/ddvk/rmfakecloud/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// refreshTokenTTL controls how long a refresh token can be exchanged for a new access token
+const refreshTokenTTL = 30 * 24 * time.Hour
+
+// accessTokenTTL controls how long a signed access token is valid for before the client
+// must exchange its refresh token for a new one
+const accessTokenTTL = 15 * time.Minute
+
+type refreshForm struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+func newRefreshTokenID() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// issueTokenPair signs a fresh, short-lived access token for user and mints a server-side
+// refresh token for it. The access token's jti is also tracked in tokenStore so it can be
+// revoked by a logout/logout-all before its natural expiry.
+func issueTokenPair(user *model.User, cfg *config.Config, tokenStore db.TokenStore) (accessToken, refreshToken string, err error) {
+	accessJTI, err := newRefreshTokenID()
+	if err != nil {
+		return "", "", err
+	}
+
+	token := user.NewAuth0Token("ui", "")
+	if claims, ok := token.Claims.(jwt.MapClaims); ok {
+		claims["exp"] = time.Now().Add(accessTokenTTL).Unix()
+		claims["jti"] = accessJTI
+	}
+	accessToken, err = token.SignedString(cfg.JWTSecretKey)
+	if err != nil {
+		return "", "", err
+	}
+	if err := tokenStore.CreateToken(accessJTI, user.Id, time.Now().Add(accessTokenTTL)); err != nil {
+		return "", "", err
+	}
+
+	refreshJTI, err := newRefreshTokenID()
+	if err != nil {
+		return "", "", err
+	}
+	if err := tokenStore.CreateToken(refreshJTI, user.Id, time.Now().Add(refreshTokenTTL)); err != nil {
+		return "", "", err
+	}
+
+	return accessToken, refreshJTI, nil
+}
+
+// TokenRevoked reports whether jti has been revoked, has expired or is unknown to the store. Called by
+// RequireActiveToken to reject access tokens issued before a logout/logout-all.
+func TokenRevoked(tokenStore db.TokenStore, jti string) bool {
+	record, err := tokenStore.GetToken(jti)
+	if err != nil || record == nil {
+		return true
+	}
+	return record.Revoked || time.Now().After(record.Expiry)
+}
+
+// RequireActiveToken aborts the request if the bearer access token's jti has been revoked.
+// It assumes the token's signature and "userId" claim have already been validated upstream;
+// it only adds the revocation check that the stateless JWT itself can't express.
+func RequireActiveToken(cfg *config.Config, tokenStore db.TokenStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if tokenString == "" {
+			c.Next()
+			return
+		}
+
+		parsed, err := jwt.Parse(tokenString, func(*jwt.Token) (interface{}, error) {
+			return cfg.JWTSecretKey, nil
+		})
+		if err != nil || !parsed.Valid {
+			c.Next()
+			return
+		}
+
+		claims, ok := parsed.Claims.(jwt.MapClaims)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		if jti, _ := claims["jti"].(string); jti != "" && TokenRevoked(tokenStore, jti) {
+			abortJSON(c, http.StatusUnauthorized, "errors.token_revoked")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// registerAuthRoutes wires up the refresh/logout endpoints on the unauthenticated group
+func registerAuthRoutes(r *gin.RouterGroup, cfg *config.Config, userStorer db.UserStorer, tokenStore db.TokenStore) {
+	r.POST("refresh", func(c *gin.Context) {
+		var form refreshForm
+		if err := c.ShouldBindJSON(&form); err != nil {
+			log.Error(err)
+			badReq(c, "errors.bad_request", err.Error())
+			return
+		}
+
+		record, err := tokenStore.GetToken(form.RefreshToken)
+		if err != nil || record == nil || record.Revoked || time.Now().After(record.Expiry) {
+			abortJSON(c, http.StatusUnauthorized, "errors.invalid_refresh_token")
+			return
+		}
+
+		user, err := userStorer.GetUser(record.UserId)
+		if err != nil || user == nil {
+			abortJSON(c, http.StatusUnauthorized, "errors.invalid_refresh_token")
+			return
+		}
+
+		// rotate: the presented refresh token is single-use
+		if err := tokenStore.RevokeToken(form.RefreshToken); err != nil {
+			log.Error("Unable to revoke rotated refresh token: ", err)
+		}
+
+		accessToken, refreshToken, err := issueTokenPair(user, cfg, tokenStore)
+		if err != nil {
+			log.Error(err)
+			badReq(c, "errors.bad_request", err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"auth_token":    accessToken,
+			"refresh_token": refreshToken,
+		})
+	})
+
+	r.POST("logout", func(c *gin.Context) {
+		var form refreshForm
+		if err := c.ShouldBindJSON(&form); err != nil {
+			log.Error(err)
+			badReq(c, "errors.bad_request", err.Error())
+			return
+		}
+
+		if err := tokenStore.RevokeToken(form.RefreshToken); err != nil {
+			log.Error("Unable to revoke refresh token: ", err)
+		}
+
+		c.Status(http.StatusOK)
+	})
+}
+
+// registerAuthedRoutes wires up auth endpoints that require an authenticated caller
+func registerAuthedRoutes(r *gin.RouterGroup, tokenStore db.TokenStore) {
+	r.POST("logout-all", func(c *gin.Context) {
+		uid := c.GetString("userId")
+		if err := tokenStore.RevokeAllForUser(uid); err != nil {
+			log.Error("Unable to revoke tokens: ", err)
+			badReq(c, "errors.unable_to_logout_others")
+			return
+		}
+
+		c.Status(http.StatusOK)
+	})
+}
@@ -0,0 +1,136 @@
+package ui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/ddvk/rmfakecloud/internal/config"
+	"github.com/ddvk/rmfakecloud/internal/db"
+	"github.com/gin-gonic/gin"
+)
+
+// fakeTokenStore is an in-memory db.TokenStore for tests
+type fakeTokenStore struct {
+	tokens map[string]*db.Token
+}
+
+func newFakeTokenStore() *fakeTokenStore {
+	return &fakeTokenStore{tokens: map[string]*db.Token{}}
+}
+
+func (s *fakeTokenStore) CreateToken(jti, userId string, expiry time.Time) error {
+	s.tokens[jti] = &db.Token{UserId: userId, Expiry: expiry}
+	return nil
+}
+
+func (s *fakeTokenStore) GetToken(jti string) (*db.Token, error) {
+	return s.tokens[jti], nil
+}
+
+func (s *fakeTokenStore) RevokeToken(jti string) error {
+	if t, ok := s.tokens[jti]; ok {
+		t.Revoked = true
+	}
+	return nil
+}
+
+func (s *fakeTokenStore) RevokeAllForUser(userId string) error {
+	for _, t := range s.tokens {
+		if t.UserId == userId {
+			t.Revoked = true
+		}
+	}
+	return nil
+}
+
+func TestTokenRevoked(t *testing.T) {
+	store := newFakeTokenStore()
+	store.CreateToken("known", "user-1", time.Now().Add(time.Hour))
+
+	if TokenRevoked(store, "unknown") != true {
+		t.Error("expected an unknown jti to be treated as revoked")
+	}
+	if TokenRevoked(store, "known") != false {
+		t.Error("expected a fresh token to not be revoked")
+	}
+
+	store.RevokeToken("known")
+	if TokenRevoked(store, "known") != true {
+		t.Error("expected a revoked token to report revoked")
+	}
+}
+
+func TestTokenRevokedExpired(t *testing.T) {
+	store := newFakeTokenStore()
+	store.CreateToken("expired", "user-1", time.Now().Add(-time.Minute))
+
+	if !TokenRevoked(store, "expired") {
+		t.Error("expected an expired token to report revoked")
+	}
+}
+
+func TestRequireActiveTokenRejectsRevokedJTI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{JWTSecretKey: []byte("test-secret")}
+	store := newFakeTokenStore()
+	store.CreateToken("revoked-jti", "user-1", time.Now().Add(time.Hour))
+	store.RevokeToken("revoked-jti")
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"userId": "user-1",
+		"jti":    "revoked-jti",
+		"exp":    time.Now().Add(time.Hour).Unix(),
+	})
+	signed, err := token.SignedString(cfg.JWTSecretKey)
+	if err != nil {
+		t.Fatalf("unable to sign test token: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(RequireActiveToken(cfg, store))
+	router.GET("/protected", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set("Authorization", "Bearer "+signed)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a revoked jti, got %d", w.Code)
+	}
+}
+
+func TestRequireActiveTokenAllowsActiveJTI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{JWTSecretKey: []byte("test-secret")}
+	store := newFakeTokenStore()
+	store.CreateToken("active-jti", "user-1", time.Now().Add(time.Hour))
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"userId": "user-1",
+		"jti":    "active-jti",
+		"exp":    time.Now().Add(time.Hour).Unix(),
+	})
+	signed, err := token.SignedString(cfg.JWTSecretKey)
+	if err != nil {
+		t.Fatalf("unable to sign test token: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(RequireActiveToken(cfg, store))
+	router.GET("/protected", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set("Authorization", "Bearer "+signed)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 for an active jti, got %d", w.Code)
+	}
+}
@@ -0,0 +1,91 @@
+package ui
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/ddvk/rmfakecloud/internal/model"
+)
+
+// fakeMetadataStorer is an in-memory db.MetadataStorer for tests
+type fakeMetadataStorer struct {
+	removeAllErr error
+}
+
+func (s *fakeMetadataStorer) GetAllMetadata(userId string) ([]*model.Metadata, error) {
+	return nil, nil
+}
+
+func (s *fakeMetadataStorer) GetMetadata(userId, id string) (*model.Metadata, error) {
+	return nil, nil
+}
+
+func (s *fakeMetadataStorer) RemoveDocument(userId, id string) error {
+	return nil
+}
+
+func (s *fakeMetadataStorer) GetThumbnail(userId, id string) (io.ReadCloser, error) {
+	return nil, nil
+}
+
+func (s *fakeMetadataStorer) RemoveAllForUser(userId string) error {
+	return s.removeAllErr
+}
+
+// fakeBlobStorer is an in-memory db.BlobStorer for tests
+type fakeBlobStorer struct {
+	removeAllErr error
+}
+
+func (s *fakeBlobStorer) RemoveAllForUser(userId string) error {
+	return s.removeAllErr
+}
+
+// fakeDeviceStorer is an in-memory db.DeviceStorer for tests
+type fakeDeviceStorer struct {
+	removeAllErr error
+}
+
+func (s *fakeDeviceStorer) RemoveAllForUser(userId string) error {
+	return s.removeAllErr
+}
+
+func TestCascadeDeleteUserAllSucceed(t *testing.T) {
+	user := &model.User{Id: "user-1", Email: "member@example.com"}
+	userStorer := newFakeUserStorer(user)
+	tokenStore := newFakeTokenStore()
+
+	result := cascadeDeleteUser("user-1", userStorer, &fakeMetadataStorer{}, &fakeBlobStorer{}, &fakeDeviceStorer{}, tokenStore)
+
+	if len(result.Failed) != 0 {
+		t.Errorf("expected no failures, got %+v", result.Failed)
+	}
+	if len(result.Succeeded) != 5 {
+		t.Errorf("expected all 5 subsystems to succeed, got %d: %+v", len(result.Succeeded), result.Succeeded)
+	}
+	if _, err := userStorer.GetUser("user-1"); err != nil {
+		t.Errorf("unexpected error fetching removed user: %v", err)
+	}
+	if u, _ := userStorer.GetUser("user-1"); u != nil {
+		t.Error("expected the user to have been removed")
+	}
+}
+
+func TestCascadeDeleteUserPartialFailure(t *testing.T) {
+	user := &model.User{Id: "user-1", Email: "member@example.com"}
+	userStorer := newFakeUserStorer(user)
+	tokenStore := newFakeTokenStore()
+
+	result := cascadeDeleteUser("user-1", userStorer, &fakeMetadataStorer{removeAllErr: errors.New("locked")}, &fakeBlobStorer{}, &fakeDeviceStorer{}, tokenStore)
+
+	if len(result.Failed) != 1 {
+		t.Fatalf("expected exactly 1 failure, got %+v", result.Failed)
+	}
+	if result.Failed[0].Subsystem != "documents" {
+		t.Errorf("expected the documents subsystem to have failed, got %q", result.Failed[0].Subsystem)
+	}
+	if len(result.Succeeded) != 4 {
+		t.Errorf("expected the other 4 subsystems to still succeed, got %d: %+v", len(result.Succeeded), result.Succeeded)
+	}
+}
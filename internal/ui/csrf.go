@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/ddvk/rmfakecloud/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	csrfHeaderName = "X-CSRF-Token"
+	csrfCookieName = "csrf_sig"
+)
+
+func signCSRFToken(secret []byte, token string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// registerCSRF adds the GET /ui/api/csrf token-minting route to r
+func registerCSRF(r *gin.RouterGroup, cfg *config.Config) {
+	r.GET("csrf", func(c *gin.Context) {
+		raw := make([]byte, 32)
+		if _, err := rand.Read(raw); err != nil {
+			abortJSON(c, http.StatusInternalServerError, "errors.internal")
+			return
+		}
+		token := base64.RawURLEncoding.EncodeToString(raw)
+		signature := signCSRFToken(cfg.JWTSecretKey, token)
+
+		c.SetCookie(csrfCookieName, signature, 0, "/", "", false, true)
+		c.JSON(http.StatusOK, gin.H{"token": token})
+	})
+}
+
+// RequireCSRF rejects state-changing requests whose X-CSRF-Token header doesn't
+// match the signature minted by GET /ui/api/csrf and stored in the csrf_sig cookie
+func RequireCSRF(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodDelete:
+		default:
+			c.Next()
+			return
+		}
+
+		token := c.GetHeader(csrfHeaderName)
+		signature, err := c.Cookie(csrfCookieName)
+		if token == "" || err != nil {
+			abortJSON(c, http.StatusForbidden, "errors.missing_csrf_token")
+			return
+		}
+
+		expected := signCSRFToken(cfg.JWTSecretKey, token)
+		if !hmac.Equal([]byte(expected), []byte(signature)) {
+			abortJSON(c, http.StatusForbidden, "errors.invalid_csrf_token")
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ddvk/rmfakecloud/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireCSRFRejectsMissingToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{JWTSecretKey: []byte("test-secret")}
+
+	router := gin.New()
+	router.Use(RequireCSRF(cfg))
+	router.POST("/do", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodPost, "/do", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a POST with no CSRF token, got %d", w.Code)
+	}
+}
+
+func TestCSRFTokenRoundTrip(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{JWTSecretKey: []byte("test-secret")}
+
+	router := gin.New()
+	registerCSRF(router.Group("/"), cfg)
+	router.Use(RequireCSRF(cfg))
+	router.POST("/do", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	mintReq := httptest.NewRequest(http.MethodGet, "/csrf", nil)
+	mintResp := httptest.NewRecorder()
+	router.ServeHTTP(mintResp, mintReq)
+
+	if mintResp.Code != http.StatusOK {
+		t.Fatalf("expected 200 minting a CSRF token, got %d", mintResp.Code)
+	}
+
+	var cookie *http.Cookie
+	for _, c := range mintResp.Result().Cookies() {
+		if c.Name == csrfCookieName {
+			cookie = c
+		}
+	}
+	if cookie == nil {
+		t.Fatal("expected GET /csrf to set the csrf_sig cookie")
+	}
+
+	token := extractJSONField(t, mintResp.Body.Bytes(), "token")
+
+	req := httptest.NewRequest(http.MethodPost, "/do", nil)
+	req.Header.Set(csrfHeaderName, token)
+	req.AddCookie(cookie)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected a matching token+cookie pair to be accepted, got %d", w.Code)
+	}
+}
+
+func extractJSONField(t *testing.T, body []byte, field string) string {
+	var parsed map[string]string
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		t.Fatalf("unable to parse response body: %v", err)
+	}
+	return parsed[field]
+}
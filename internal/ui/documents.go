@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"io"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ddvk/rmfakecloud/internal/db"
+	"github.com/ddvk/rmfakecloud/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// rootParent is the synthetic id of the top-level folder
+const rootParent = "root"
+
+// Document is a single document or folder in the tree
+type Document struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	ImageUrl     string `json:"imageUrl"`
+	ParentId     string `json:"parentId"`
+	LastModified string `json:"lastModified"`
+	Size         int64  `json:"size"`
+}
+
+func metadataToDocument(m *model.Metadata) Document {
+	doc := Document{
+		ID:           m.ID,
+		Name:         m.VissibleName,
+		Type:         m.Type,
+		ParentId:     m.Parent,
+		LastModified: m.ModifiedClient,
+		Size:         m.SizeInBytes,
+	}
+	if doc.ParentId == "" {
+		doc.ParentId = rootParent
+	}
+	if m.Type == model.DocumentType {
+		doc.ImageUrl = "/ui/api/documents/" + m.ID + "/thumbnail"
+	}
+	return doc
+}
+
+// registerDocumentRoutes wires up the document tree endpoints backed by the MetadataStorer
+func registerDocumentRoutes(r *gin.RouterGroup, metaStorer db.MetadataStorer) {
+	r.GET("list", func(c *gin.Context) {
+		uid := c.GetString("userId")
+		parent := c.DefaultQuery("parent", rootParent)
+
+		allMetadata, err := metaStorer.GetAllMetadata(uid)
+		if err != nil {
+			log.Error("Unable to get metadata: ", err)
+			abortJSON(c, http.StatusInternalServerError, "errors.unable_to_get_documents")
+			return
+		}
+
+		documents := []Document{}
+		for _, m := range allMetadata {
+			doc := metadataToDocument(m)
+			if doc.ParentId == parent {
+				documents = append(documents, doc)
+			}
+		}
+
+		c.JSON(http.StatusOK, documents)
+	})
+
+	r.GET("documents/:id", func(c *gin.Context) {
+		uid := c.GetString("userId")
+		id := c.Param("id")
+
+		m, err := metaStorer.GetMetadata(uid, id)
+		if err != nil {
+			log.Error("Unable to find document: ", err)
+			badReq(c, "errors.document_not_found")
+			return
+		}
+
+		c.JSON(http.StatusOK, metadataToDocument(m))
+	})
+
+	r.DELETE("documents/:id", func(c *gin.Context) {
+		uid := c.GetString("userId")
+		id := c.Param("id")
+
+		if err := metaStorer.RemoveDocument(uid, id); err != nil {
+			log.Error("Unable to remove document: ", err)
+			badReq(c, "errors.unable_to_remove_document")
+			return
+		}
+
+		c.Status(http.StatusOK)
+	})
+
+	r.GET("documents/:id/thumbnail", func(c *gin.Context) {
+		uid := c.GetString("userId")
+		id := c.Param("id")
+
+		thumbnail, err := metaStorer.GetThumbnail(uid, id)
+		if err != nil {
+			log.Error("Unable to get thumbnail: ", err)
+			c.Status(http.StatusNotFound)
+			return
+		}
+		defer thumbnail.Close()
+
+		c.Header("Content-Type", "image/png")
+		if _, err := io.Copy(c.Writer, thumbnail); err != nil {
+			log.Error("Unable to stream thumbnail: ", err)
+		}
+	})
+}
@@ -0,0 +1,22 @@
+package ui
+
+import (
+	"net/http"
+
+	"github.com/ddvk/rmfakecloud/internal/i18n"
+	"github.com/gin-gonic/gin"
+)
+
+// abortJSON aborts the request with a localized message alongside the stable message id,
+// so the React UI can render its own strings when it wants to
+func abortJSON(c *gin.Context, status int, code string, args ...interface{}) {
+	c.AbortWithStatusJSON(status, gin.H{
+		"code":    code,
+		"message": i18n.FromContext(c).T(code, args...),
+	})
+}
+
+// badReq is a shorthand for abortJSON with a 400 status
+func badReq(c *gin.Context, code string, args ...interface{}) {
+	abortJSON(c, http.StatusBadRequest, code, args...)
+}
@@ -0,0 +1,23 @@
+package ui
+
+import (
+	"net/http"
+
+	"github.com/ddvk/rmfakecloud/internal/db"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdmin aborts the request with 403 unless the authenticated user is an admin
+func RequireAdmin(userStorer db.UserStorer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid := c.GetString("userId")
+		user, err := userStorer.GetUser(uid)
+		if err != nil || user == nil || !user.IsAdmin {
+			abortJSON(c, http.StatusForbidden, "errors.admin_required")
+			return
+		}
+
+		c.Set("currentUser", user)
+		c.Next()
+	}
+}
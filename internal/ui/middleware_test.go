@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ddvk/rmfakecloud/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// fakeUserStorer is an in-memory db.UserStorer for tests
+type fakeUserStorer struct {
+	byId map[string]*model.User
+}
+
+func newFakeUserStorer(users ...*model.User) *fakeUserStorer {
+	s := &fakeUserStorer{byId: map[string]*model.User{}}
+	for _, u := range users {
+		s.byId[u.Id] = u
+	}
+	return s
+}
+
+func (s *fakeUserStorer) GetUsers() ([]*model.User, error) {
+	users := make([]*model.User, 0, len(s.byId))
+	for _, u := range s.byId {
+		users = append(users, u)
+	}
+	return users, nil
+}
+
+func (s *fakeUserStorer) GetUser(id string) (*model.User, error) {
+	return s.byId[id], nil
+}
+
+func (s *fakeUserStorer) GetUserByEmail(email string) (*model.User, error) {
+	for _, u := range s.byId {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *fakeUserStorer) RegisterUser(user *model.User) error {
+	s.byId[user.Id] = user
+	return nil
+}
+
+func (s *fakeUserStorer) UpdateUser(user *model.User) error {
+	s.byId[user.Id] = user
+	return nil
+}
+
+func (s *fakeUserStorer) RemoveUser(id string) error {
+	delete(s.byId, id)
+	return nil
+}
+
+func newContextWithUser(userId string) (*gin.Context, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Set("userId", userId)
+	return c, w
+}
+
+func TestRequireAdminRejectsNonAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	member := &model.User{Id: "user-1", Email: "member@example.com", IsAdmin: false}
+	storer := newFakeUserStorer(member)
+
+	c, w := newContextWithUser("user-1")
+	RequireAdmin(storer)(c)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a non-admin, got %d", w.Code)
+	}
+}
+
+func TestRequireAdminAllowsAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	admin := &model.User{Id: "admin-1", Email: "admin@example.com", IsAdmin: true}
+	storer := newFakeUserStorer(admin)
+
+	c, w := newContextWithUser("admin-1")
+	RequireAdmin(storer)(c)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected no abort for an admin, got status %d", w.Code)
+	}
+	if c.IsAborted() {
+		t.Error("expected the admin request to not be aborted")
+	}
+}
+
+func TestCanAccessUserSelfOrAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	member := &model.User{Id: "user-1", Email: "member@example.com", IsAdmin: false}
+	admin := &model.User{Id: "admin-1", Email: "admin@example.com", IsAdmin: true}
+	storer := newFakeUserStorer(member, admin)
+
+	c, _ := newContextWithUser("user-1")
+	if _, allowed := canAccessUser(c, storer, "user-1"); !allowed {
+		t.Error("expected a user to access their own record")
+	}
+	if _, allowed := canAccessUser(c, storer, "someone-else"); allowed {
+		t.Error("expected a non-admin to be denied access to another user's record")
+	}
+
+	c, _ = newContextWithUser("admin-1")
+	if _, allowed := canAccessUser(c, storer, "user-1"); !allowed {
+		t.Error("expected an admin to access any user's record")
+	}
+}
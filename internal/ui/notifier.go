@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"fmt"
+	"net/smtp"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ddvk/rmfakecloud/internal/config"
+)
+
+// Notifier delivers an out-of-band message to a user, e.g. a password reset link
+type Notifier interface {
+	Notify(to, subject, body string) error
+}
+
+// logNotifier is the zero-config default: it just logs the message, for instances that
+// have no SMTP relay configured
+type logNotifier struct{}
+
+func (logNotifier) Notify(to, subject, body string) error {
+	log.Infof("notification for %s (%s):\n%s", to, subject, body)
+	return nil
+}
+
+// smtpNotifier sends the message through a configured SMTP relay
+type smtpNotifier struct {
+	host string
+	port string
+	user string
+	pass string
+	from string
+}
+
+func (n *smtpNotifier) Notify(to, subject, body string) error {
+	addr := n.host + ":" + n.port
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s", n.from, to, subject, body)
+
+	var auth smtp.Auth
+	if n.user != "" {
+		auth = smtp.PlainAuth("", n.user, n.pass, n.host)
+	}
+	return smtp.SendMail(addr, auth, n.from, []string{to}, []byte(msg))
+}
+
+// NewNotifier returns an SMTP-backed Notifier if cfg has SMTP settings, otherwise the logging default
+func NewNotifier(cfg *config.Config) Notifier {
+	if cfg.SMTPHost == "" {
+		return logNotifier{}
+	}
+	return &smtpNotifier{
+		host: cfg.SMTPHost,
+		port: cfg.SMTPPort,
+		user: cfg.SMTPUser,
+		pass: cfg.SMTPPass,
+		from: cfg.SMTPFrom,
+	}
+}
@@ -0,0 +1,160 @@
+package ui
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ddvk/rmfakecloud/internal/config"
+	"github.com/ddvk/rmfakecloud/internal/db"
+	"github.com/ddvk/rmfakecloud/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// passwordResetTTL is how long a password reset token stays valid
+const passwordResetTTL = 30 * time.Minute
+
+// passwordHashPrefixLen is how much of a digest of the current password hash gets baked into
+// a reset token, so the token is invalidated the instant the password changes
+const passwordHashPrefixLen = 8
+
+// passwordHashPrefix digests the whole stored hash first: the leading characters of the hash
+// itself are its algorithm header, which stays the same when the password changes
+func passwordHashPrefix(user *model.User) string {
+	sum := sha256.Sum256([]byte(user.Password))
+	return hex.EncodeToString(sum[:])[:passwordHashPrefixLen]
+}
+
+// generatePasswordResetToken mints a signed, time-limited token for resetting user's password
+func generatePasswordResetToken(user *model.User, secret []byte) string {
+	expiry := time.Now().Add(passwordResetTTL).Unix()
+	payload := fmt.Sprintf("%s|%d|%s", user.Id, expiry, passwordHashPrefix(user))
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	signature := mac.Sum(nil)
+
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// verifyPasswordResetToken checks the signature and expiry, and that the password hasn't
+// already changed since the token was issued
+func verifyPasswordResetToken(token string, secret []byte, userStorer db.UserStorer) (*model.User, error) {
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("malformed reset token")
+	}
+
+	rawPayload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("malformed reset token")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("malformed reset token")
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(rawPayload)
+	if !hmac.Equal(mac.Sum(nil), signature) {
+		return nil, fmt.Errorf("invalid reset token")
+	}
+
+	fields := strings.SplitN(string(rawPayload), "|", 3)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("malformed reset token")
+	}
+	userId, expiryField, hashPrefix := fields[0], fields[1], fields[2]
+
+	expiryUnix, err := strconv.ParseInt(expiryField, 10, 64)
+	if err != nil || time.Now().After(time.Unix(expiryUnix, 0)) {
+		return nil, fmt.Errorf("reset token expired")
+	}
+
+	user, err := userStorer.GetUser(userId)
+	if err != nil || user == nil {
+		return nil, fmt.Errorf("invalid reset token")
+	}
+	if passwordHashPrefix(user) != hashPrefix {
+		return nil, fmt.Errorf("reset token no longer valid")
+	}
+
+	return user, nil
+}
+
+func sendPasswordResetNotification(notifier Notifier, user *model.User, token string) {
+	body := fmt.Sprintf("Use this token to reset your password (valid for %d minutes): %s", int(passwordResetTTL.Minutes()), token)
+	if err := notifier.Notify(user.Email, "Password reset", body); err != nil {
+		log.Error("Unable to send password reset notification: ", err)
+	}
+}
+
+type forgotPasswordForm struct {
+	Email string `json:"email"`
+}
+
+type resetPasswordForm struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// registerPasswordRoutes wires up the password reset endpoints on the unauthenticated group
+func registerPasswordRoutes(r *gin.RouterGroup, cfg *config.Config, userStorer db.UserStorer, notifier Notifier) {
+	r.POST("password/forgot", func(c *gin.Context) {
+		var form forgotPasswordForm
+		if err := c.ShouldBindJSON(&form); err != nil {
+			log.Error(err)
+			badReq(c, "errors.bad_request", err.Error())
+			return
+		}
+
+		// never reveal whether the account exists
+		defer c.Status(http.StatusOK)
+
+		user, err := userStorer.GetUserByEmail(form.Email)
+		if err != nil || user == nil {
+			return
+		}
+
+		token := generatePasswordResetToken(user, cfg.JWTSecretKey)
+		sendPasswordResetNotification(notifier, user, token)
+	})
+
+	r.POST("password/reset", func(c *gin.Context) {
+		var form resetPasswordForm
+		if err := c.ShouldBindJSON(&form); err != nil {
+			log.Error(err)
+			badReq(c, "errors.bad_request", err.Error())
+			return
+		}
+
+		user, err := verifyPasswordResetToken(form.Token, cfg.JWTSecretKey, userStorer)
+		if err != nil {
+			log.Error(err)
+			abortJSON(c, http.StatusUnauthorized, "errors.invalid_reset_token")
+			return
+		}
+
+		if err := user.SetPassword(form.Password); err != nil {
+			log.Error(err)
+			badReq(c, "errors.bad_request", err.Error())
+			return
+		}
+
+		if err := userStorer.UpdateUser(user); err != nil {
+			log.Error(err)
+			badReq(c, "errors.bad_request", err.Error())
+			return
+		}
+
+		c.Status(http.StatusOK)
+	})
+}
@@ -0,0 +1,135 @@
+package ui
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ddvk/rmfakecloud/internal/config"
+	"github.com/ddvk/rmfakecloud/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// fakeNotifier records every notification it's asked to send, instead of logging or mailing it
+type fakeNotifier struct {
+	sent []string
+}
+
+func (n *fakeNotifier) Notify(to, subject, body string) error {
+	n.sent = append(n.sent, to)
+	return nil
+}
+
+func newTestUser(t *testing.T, email, password string) *model.User {
+	user, err := model.NewUser(email, password)
+	if err != nil {
+		t.Fatalf("unable to create test user: %v", err)
+	}
+	user.Id = email
+	return user
+}
+
+// resetTokenWithExpiry mirrors generatePasswordResetToken but lets the test pin an
+// arbitrary expiry, so expiry can be exercised without waiting out passwordResetTTL
+func resetTokenWithExpiry(user *model.User, secret []byte, expiry time.Time) string {
+	payload := fmt.Sprintf("%s|%d|%s", user.Id, expiry.Unix(), passwordHashPrefix(user))
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	signature := mac.Sum(nil)
+
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestPasswordResetTokenRoundTrip(t *testing.T) {
+	secret := []byte("test-secret")
+	user := newTestUser(t, "user@example.com", "correct-password")
+	storer := newFakeUserStorer(user)
+
+	token := generatePasswordResetToken(user, secret)
+
+	verified, err := verifyPasswordResetToken(token, secret, storer)
+	if err != nil {
+		t.Fatalf("expected a freshly minted token to verify, got error: %v", err)
+	}
+	if verified.Id != user.Id {
+		t.Errorf("expected to resolve %s, got %s", user.Id, verified.Id)
+	}
+}
+
+func TestPasswordResetTokenRejectsTamperedSignature(t *testing.T) {
+	secret := []byte("test-secret")
+	user := newTestUser(t, "user@example.com", "correct-password")
+	storer := newFakeUserStorer(user)
+
+	token := generatePasswordResetToken(user, secret)
+	parts := strings.SplitN(token, ".", 2)
+	tampered := parts[0] + "." + strings.Repeat("A", len(parts[1]))
+
+	if _, err := verifyPasswordResetToken(tampered, secret, storer); err == nil {
+		t.Error("expected a tampered signature to be rejected")
+	}
+}
+
+func TestPasswordResetTokenRejectsExpired(t *testing.T) {
+	secret := []byte("test-secret")
+	user := newTestUser(t, "user@example.com", "correct-password")
+	storer := newFakeUserStorer(user)
+
+	token := resetTokenWithExpiry(user, secret, time.Now().Add(-time.Minute))
+
+	if _, err := verifyPasswordResetToken(token, secret, storer); err == nil {
+		t.Error("expected an expired token to be rejected")
+	}
+}
+
+func TestPasswordResetTokenInvalidatedByPasswordChange(t *testing.T) {
+	secret := []byte("test-secret")
+	user := newTestUser(t, "user@example.com", "correct-password")
+	storer := newFakeUserStorer(user)
+
+	token := generatePasswordResetToken(user, secret)
+
+	if err := user.SetPassword("a-brand-new-password"); err != nil {
+		t.Fatalf("unable to change password: %v", err)
+	}
+
+	if _, err := verifyPasswordResetToken(token, secret, storer); err == nil {
+		t.Error("expected a token minted before a password change to be rejected")
+	}
+}
+
+func TestForgotPasswordDoesNotRevealAccountExistence(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{JWTSecretKey: []byte("test-secret")}
+	user := newTestUser(t, "known@example.com", "correct-password")
+	storer := newFakeUserStorer(user)
+	notifier := &fakeNotifier{}
+
+	router := gin.New()
+	r := router.Group("/")
+	registerPasswordRoutes(r, cfg, storer, notifier)
+
+	for _, email := range []string{"known@example.com", "unknown@example.com"} {
+		body := strings.NewReader(fmt.Sprintf(`{"email":%q}`, email))
+		req := httptest.NewRequest(http.MethodPost, "/password/forgot", body)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("expected 200 for %s regardless of account existence, got %d", email, w.Code)
+		}
+	}
+
+	if len(notifier.sent) != 1 || notifier.sent[0] != "known@example.com" {
+		t.Errorf("expected a notification only for the known account, got %v", notifier.sent)
+	}
+}
@@ -0,0 +1,117 @@
+package ui
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tokenBucket is a simple per-key token bucket: `burst` tokens refilled at `rps` per second
+type tokenBucket struct {
+	mu         sync.Mutex
+	tokens     float64
+	rps        float64
+	burst      float64
+	lastRefill time.Time
+}
+
+func newTokenBucket(rps float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		tokens:     float64(burst),
+		rps:        rps,
+		burst:      float64(burst),
+		lastRefill: time.Now(),
+	}
+}
+
+func (b *tokenBucket) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(b.lastRefill).Seconds()
+	b.lastRefill = now
+
+	b.tokens += elapsed * b.rps
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// bucketIdleTimeout is how long a key's bucket may sit unused before it's evicted. Keys are
+// attacker-controlled (client IP, or the login form's email), so the map can't be left to
+// grow without bound.
+const bucketIdleTimeout = 10 * time.Minute
+
+// keyedLimiter keeps one tokenBucket per key (e.g. client IP or email)
+type keyedLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	rps     float64
+	burst   int
+}
+
+func newKeyedLimiter(rps float64, burst int) *keyedLimiter {
+	l := &keyedLimiter{
+		buckets: map[string]*tokenBucket{},
+		rps:     rps,
+		burst:   burst,
+	}
+	go l.evictLoop()
+	return l
+}
+
+// evictLoop periodically sweeps buckets that have been idle longer than bucketIdleTimeout
+func (l *keyedLimiter) evictLoop() {
+	ticker := time.NewTicker(bucketIdleTimeout)
+	for range ticker.C {
+		l.evictStale()
+	}
+}
+
+func (l *keyedLimiter) evictStale() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, bucket := range l.buckets {
+		bucket.mu.Lock()
+		idle := time.Since(bucket.lastRefill)
+		bucket.mu.Unlock()
+		if idle > bucketIdleTimeout {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func (l *keyedLimiter) allow(key string) bool {
+	l.mu.Lock()
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = newTokenBucket(l.rps, l.burst)
+		l.buckets[key] = bucket
+	}
+	l.mu.Unlock()
+
+	return bucket.allow()
+}
+
+// RateLimit aborts with 429 once the limiter for the request's client IP runs dry
+func RateLimit(limiter *keyedLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !limiter.allow(c.ClientIP()) {
+			c.Header("Retry-After", strconv.Itoa(int(1/limiter.rps)+1))
+			abortJSON(c, http.StatusTooManyRequests, "errors.too_many_requests")
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,33 @@
+package ui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeyedLimiterEvictsStaleBuckets(t *testing.T) {
+	limiter := newKeyedLimiter(1, 1)
+
+	limiter.allow("attacker@example.com")
+	if len(limiter.buckets) != 1 {
+		t.Fatalf("expected 1 bucket, got %d", len(limiter.buckets))
+	}
+
+	limiter.buckets["attacker@example.com"].lastRefill = time.Now().Add(-2 * bucketIdleTimeout)
+	limiter.evictStale()
+
+	if len(limiter.buckets) != 0 {
+		t.Errorf("expected the stale bucket to be evicted, got %d remaining", len(limiter.buckets))
+	}
+}
+
+func TestKeyedLimiterKeepsActiveBuckets(t *testing.T) {
+	limiter := newKeyedLimiter(1, 1)
+
+	limiter.allow("10.0.0.1")
+	limiter.evictStale()
+
+	if len(limiter.buckets) != 1 {
+		t.Errorf("expected the recently used bucket to survive a sweep, got %d", len(limiter.buckets))
+	}
+}
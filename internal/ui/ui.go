@@ -3,11 +3,13 @@ package ui
 import (
 	"net/http"
 	"path"
+	"sync"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/ddvk/rmfakecloud/internal/config"
 	"github.com/ddvk/rmfakecloud/internal/db"
+	"github.com/ddvk/rmfakecloud/internal/i18n"
 	"github.com/ddvk/rmfakecloud/internal/model"
 	"github.com/ddvk/rmfakecloud/internal/webassets"
 	"github.com/gin-gonic/gin"
@@ -45,77 +47,78 @@ func (w ReactAppWrapper) Register(router *gin.Engine) {
 
 }
 
-// Document is a single document
-type Document struct {
-	ID       string `json:id`
-	Name     string `json:name`
-	ImageUrl string `json:imageUrl`
-	ParentId string `json:parentId`
-}
-
-// DocumentList is a list of documents
-type DocumentList struct {
-	Documents []Document `json:documents`
-}
-
-func badReq(c *gin.Context, message string) {
-	c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": message})
-}
-
 type loginForm struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 }
 
+// registerMu serializes the existence-check-then-insert below, so two concurrent
+// registrations can't both observe an empty user list and both become admin
+var registerMu sync.Mutex
+
 // RegisterUI add the react ui
-func RegisterUI(e *gin.Engine, cfg *config.Config, userStorer db.UserStorer) {
+func RegisterUI(e *gin.Engine, cfg *config.Config, userStorer db.UserStorer, tokenStore db.TokenStore) {
 	staticWrapper := ReactAppWrapper{
 		fs:     webassets.Assets,
 		prefix: "/static",
 	}
 	staticWrapper.Register(e)
 
-	r := e.Group("/ui/api")
+	ipLimiter := newKeyedLimiter(cfg.RateLimitRPS, cfg.RateLimitBurst)
+	loginLimiter := newKeyedLimiter(cfg.LoginRateLimitRPS, cfg.LoginRateLimitBurst)
+
+	r := e.Group("/ui/api", i18n.Middleware(), RateLimit(ipLimiter), RequireCSRF(cfg))
+	registerCSRF(r, cfg)
+
 	r.POST("register", func(c *gin.Context) {
 		if !cfg.RegistrationOpen {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Registrations are closed"})
+			abortJSON(c, http.StatusForbidden, "errors.registrations_closed")
 			return
 		}
 
 		var form loginForm
 		if err := c.ShouldBindJSON(&form); err != nil {
 			log.Error(err)
-			badReq(c, err.Error())
+			badReq(c, "errors.bad_request", err.Error())
 			return
 		}
 
+		registerMu.Lock()
+		defer registerMu.Unlock()
+
 		// Check this user doesn't already exist
-		users, err := userStorer.GetUsers()
+		existing, err := userStorer.GetUserByEmail(form.Email)
 		if err != nil {
 			log.Error(err)
-			badReq(c, err.Error())
+			badReq(c, "errors.bad_request", err.Error())
+			return
+		}
+		if existing != nil {
+			badReq(c, "errors.already_registered", form.Email)
 			return
 		}
 
-		//TODO: replace with map/index search
-		for _, u := range users {
-			if u.Email == form.Email {
-				badReq(c, form.Email+" is already registered.")
-				return
-			}
+		users, err := userStorer.GetUsers()
+		if err != nil {
+			log.Error(err)
+			badReq(c, "errors.bad_request", err.Error())
+			return
 		}
 
 		user, err := model.NewUser(form.Email, form.Password)
 		if err != nil {
 			log.Error(err)
-			badReq(c, err.Error())
+			badReq(c, "errors.bad_request", err.Error())
 			return
 		}
 
+		// the very first account to register becomes the admin
+		user.IsAdmin = len(users) == 0
+
 		err = userStorer.RegisterUser(user)
 		if err != nil {
 			log.Error(err)
-			badReq(c, err.Error())
+			badReq(c, "errors.bad_request", err.Error())
 			return
 		}
 
@@ -126,81 +129,77 @@ func RegisterUI(e *gin.Engine, cfg *config.Config, userStorer db.UserStorer) {
 		var form loginForm
 		if err := c.ShouldBindJSON(&form); err != nil {
 			log.Error(err)
-			badReq(c, err.Error())
+			badReq(c, "errors.bad_request", err.Error())
 			return
 		}
 
-		// Try to find the user
-		users, err := userStorer.GetUsers()
-		if err != nil {
-			log.Error(err)
-			badReq(c, err.Error())
+		if !loginLimiter.allow(form.Email) {
+			c.Header("Retry-After", "1")
+			abortJSON(c, http.StatusTooManyRequests, "errors.too_many_login_attempts")
 			return
 		}
 
-		var user *model.User
-		for _, u := range users {
-			if form.Email == u.Email {
-				user = u
-			}
+		user, err := userStorer.GetUserByEmail(form.Email)
+		if err != nil {
+			log.Error(err)
+			badReq(c, "errors.bad_request", err.Error())
+			return
 		}
 
 		if user == nil {
-			log.Error(err)
-			c.AbortWithStatusJSON(http.StatusUnauthorized, "Invalid email or password")
+			abortJSON(c, http.StatusUnauthorized, "errors.invalid_credentials")
 			return
 		}
 
 		if ok, err := user.CheckPassword(form.Password); err != nil || !ok {
 			log.Error(err)
-			c.AbortWithStatusJSON(http.StatusUnauthorized, "Invalid email or password")
+			abortJSON(c, http.StatusUnauthorized, "errors.invalid_credentials")
 			return
 		}
 
-		token := user.NewAuth0Token("ui", "")
-
-		tokenString, err := token.SignedString(cfg.JWTSecretKey)
-
+		accessToken, refreshToken, err := issueTokenPair(user, cfg, tokenStore)
 		if err != nil {
-			badReq(c, err.Error())
+			log.Error(err)
+			badReq(c, "errors.bad_request", err.Error())
 			return
 		}
 
 		loginResponse := map[string]interface{}{
-			"user":       user,
-			"auth_token": tokenString,
+			"user":          user,
+			"auth_token":    accessToken,
+			"refresh_token": refreshToken,
 		}
 
 		c.JSON(http.StatusOK, loginResponse)
 	})
+
+	registerAuthRoutes(r, cfg, userStorer, tokenStore)
+	registerPasswordRoutes(r, cfg, userStorer, NewNotifier(cfg))
 }
 
 // RegisterUIAuth registers methods that require authentication
-func RegisterUIAuth(e *gin.RouterGroup, metaStorer db.MetadataStorer, userStorer db.UserStorer) {
-	r := e.Group("/ui/api")
+func RegisterUIAuth(e *gin.RouterGroup, cfg *config.Config, metaStorer db.MetadataStorer, userStorer db.UserStorer, blobStorer db.BlobStorer, deviceStorer db.DeviceStorer, tokenStore db.TokenStore) {
+	r := e.Group("/ui/api", i18n.Middleware(), RequireActiveToken(cfg, tokenStore), RequireCSRF(cfg))
 
 	r.GET("newcode", func(c *gin.Context) {
 		uid := c.GetString("userId")
 		if uid == "" {
 			log.Error("Unable to find userId in context")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
-			c.Abort()
+			abortJSON(c, http.StatusInternalServerError, "errors.internal")
 			return
 		}
 
 		user, err := userStorer.GetUser(uid)
 		if err != nil {
 			log.Error("Unable to find user: ", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			c.Abort()
+			abortJSON(c, http.StatusInternalServerError, "errors.bad_request", err.Error())
 			return
 		}
 
 		code, err := user.NewUserCode()
 		if err != nil {
 			log.Error("Unable to generate new device code: ", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to generate new code"})
-			c.Abort()
+			abortJSON(c, http.StatusInternalServerError, "errors.unable_to_generate_code")
 			return
 		}
 
@@ -209,123 +208,7 @@ func RegisterUIAuth(e *gin.RouterGroup, metaStorer db.MetadataStorer, userStorer
 		c.JSON(http.StatusOK, code)
 	})
 
-	r.GET("list", func(c *gin.Context) {
-		documentList := DocumentList{
-			Documents: []Document{
-				{
-					ID:       "001",
-					Name:     "The Adventures of Huckleberry Finn by Mark Twain",
-					ImageUrl: "https://picsum.photos/100/150",
-					ParentId: "root",
-				},
-				{
-					ID:       "002",
-					Name:     "The Great Gatsby by F. Scott Fizgerald",
-					ImageUrl: "https://images-na.ssl-images-amazon.com/images/I/41iers%2BHLSL._SL160_.jpg",
-					ParentId: "root",
-				},
-				{
-					ID:       "003",
-					Name:     "The Stories of Anton Chekhov by Anton Checkhov",
-					ImageUrl: "https://picsum.photos/100/150",
-					ParentId: "root",
-				},
-				{
-					ID:       "004",
-					Name:     "War and Peace by Leo Tolstoy",
-					ImageUrl: "https://picsum.photos/100/150",
-					ParentId: "root",
-				},
-
-				{
-					ID:       "005",
-					Name:     " Madame Bovary by Gustav Flaubert",
-					ImageUrl: "https://picsum.photos/100/150",
-					ParentId: "root",
-				},
-
-				{
-					ID:       "006",
-					Name:     "The Adventures of Huckleberry Finn by Mark Twain",
-					ImageUrl: "https://picsum.photos/100/150",
-					ParentId: "root",
-				},
-
-				{
-					ID:       "007",
-					Name:     " The Brothers Karamazov by Fyodor Dostoyevsky",
-					ImageUrl: "https://picsum.photos/100/150",
-					ParentId: "root",
-				},
-
-				{
-					ID:       "008",
-					Name:     "Don Quixote by Miguel de Cervantes",
-					ImageUrl: "https://m.media-amazon.com/images/I/51nBHIQv6zL._SL160_.jpg",
-					ParentId: "root",
-				},
-
-				{
-					ID:       "009",
-					Name:     "Ulysses by James Joyce",
-					ImageUrl: "https://picsum.photos/100/150",
-					ParentId: "root",
-				},
-				{
-					ID:       "010",
-					Name:     "Crime and Punishment by Fyodor Dostoyevsky",
-					ImageUrl: "https://picsum.photos/100/150",
-					ParentId: "root",
-				},
-			},
-		}
-		c.JSON(http.StatusOK, documentList.Documents)
-	})
-
-	r.GET("users", func(c *gin.Context) { 
-
-		// Try to find the user
-		users, err := userStorer.GetUsers()
-
-		for _, u := range users {
-			u.Password = ""
-		}
-
-		if err != nil {
-			log.Error(err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to get users."})
-			c.Abort()
-			return
-		}
-
-		c.JSON(http.StatusOK, users)
-	})
-
-	r.GET("/users/:userid", func(c *gin.Context) {
-		userid := c.Param("userid")
-		log.Printf("Requested: %s\n", userid)
-
-		// Try to find the user
-		users, err := userStorer.GetUsers()
-		if err != nil {
-			log.Error(err)
-			badReq(c, err.Error())
-			return
-		}
-
-		var user *model.User
-		for _, u := range users {
-			if userid == u.Id {
-				user = u
-			}
-		}
-
-		if user == nil {
-			log.Error(err)
-			c.JSON(http.StatusUnauthorized, "Invalid user")
-			return
-		}
-
-		c.JSON(http.StatusOK, user)
-	})
+	registerDocumentRoutes(r, metaStorer)
+	registerUserRoutes(r, cfg, userStorer, metaStorer, blobStorer, deviceStorer, tokenStore, NewNotifier(cfg))
+	registerAuthedRoutes(r, tokenStore)
 }
\ No newline at end of file
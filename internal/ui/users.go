@@ -0,0 +1,242 @@
+package ui
+
+import (
+	"net/http"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ddvk/rmfakecloud/internal/config"
+	"github.com/ddvk/rmfakecloud/internal/db"
+	"github.com/ddvk/rmfakecloud/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+type userForm struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	IsAdmin  bool   `json:"isAdmin"`
+}
+
+// canAccessUser returns the requesting user and whether they may act on targetId,
+// i.e. they are an admin or they're operating on their own record
+func canAccessUser(c *gin.Context, userStorer db.UserStorer, targetId string) (*model.User, bool) {
+	requester, err := userStorer.GetUser(c.GetString("userId"))
+	if err != nil || requester == nil {
+		return nil, false
+	}
+	return requester, requester.IsAdmin || requester.Id == targetId
+}
+
+// registerUserRoutes wires up the /ui/api/users endpoints
+func registerUserRoutes(r *gin.RouterGroup, cfg *config.Config, userStorer db.UserStorer, metaStorer db.MetadataStorer, blobStorer db.BlobStorer, deviceStorer db.DeviceStorer, tokenStore db.TokenStore, notifier Notifier) {
+	r.GET("users", RequireAdmin(userStorer), func(c *gin.Context) {
+		users, err := userStorer.GetUsers()
+		if err != nil {
+			log.Error(err)
+			abortJSON(c, http.StatusInternalServerError, "errors.unable_to_get_users")
+			return
+		}
+
+		for _, u := range users {
+			u.Password = ""
+		}
+
+		c.JSON(http.StatusOK, users)
+	})
+
+	r.POST("users", RequireAdmin(userStorer), func(c *gin.Context) {
+		var form userForm
+		if err := c.ShouldBindJSON(&form); err != nil {
+			log.Error(err)
+			badReq(c, "errors.bad_request", err.Error())
+			return
+		}
+
+		existing, err := userStorer.GetUserByEmail(form.Email)
+		if err != nil {
+			log.Error(err)
+			badReq(c, "errors.bad_request", err.Error())
+			return
+		}
+		if existing != nil {
+			badReq(c, "errors.already_registered", form.Email)
+			return
+		}
+
+		user, err := model.NewUser(form.Email, form.Password)
+		if err != nil {
+			log.Error(err)
+			badReq(c, "errors.bad_request", err.Error())
+			return
+		}
+		user.IsAdmin = form.IsAdmin
+
+		if err := userStorer.RegisterUser(user); err != nil {
+			log.Error(err)
+			badReq(c, "errors.bad_request", err.Error())
+			return
+		}
+
+		user.Password = ""
+		c.JSON(http.StatusOK, user)
+	})
+
+	r.GET("/users/:userid", func(c *gin.Context) {
+		userid := c.Param("userid")
+		log.Printf("Requested: %s\n", userid)
+
+		_, allowed := canAccessUser(c, userStorer, userid)
+		if !allowed {
+			abortJSON(c, http.StatusForbidden, "errors.not_allowed")
+			return
+		}
+
+		user, err := userStorer.GetUser(userid)
+		if err != nil || user == nil {
+			abortJSON(c, http.StatusUnauthorized, "errors.invalid_user")
+			return
+		}
+
+		user.Password = ""
+		c.JSON(http.StatusOK, user)
+	})
+
+	r.PUT("/users/:userid", func(c *gin.Context) {
+		userid := c.Param("userid")
+
+		requester, allowed := canAccessUser(c, userStorer, userid)
+		if !allowed {
+			abortJSON(c, http.StatusForbidden, "errors.not_allowed")
+			return
+		}
+
+		user, err := userStorer.GetUser(userid)
+		if err != nil || user == nil {
+			badReq(c, "errors.invalid_user")
+			return
+		}
+
+		var form userForm
+		if err := c.ShouldBindJSON(&form); err != nil {
+			log.Error(err)
+			badReq(c, "errors.bad_request", err.Error())
+			return
+		}
+
+		if form.Email != "" && form.Email != user.Email {
+			existing, err := userStorer.GetUserByEmail(form.Email)
+			if err != nil {
+				log.Error(err)
+				badReq(c, "errors.bad_request", err.Error())
+				return
+			}
+			if existing != nil && existing.Id != user.Id {
+				badReq(c, "errors.already_registered", form.Email)
+				return
+			}
+			user.Email = form.Email
+		}
+		if form.Password != "" {
+			if err := user.SetPassword(form.Password); err != nil {
+				log.Error(err)
+				badReq(c, "errors.bad_request", err.Error())
+				return
+			}
+		}
+		// only an admin may promote or demote an account
+		if requester.IsAdmin {
+			user.IsAdmin = form.IsAdmin
+		}
+
+		if err := userStorer.UpdateUser(user); err != nil {
+			log.Error(err)
+			badReq(c, "errors.bad_request", err.Error())
+			return
+		}
+
+		user.Password = ""
+		c.JSON(http.StatusOK, user)
+	})
+
+	r.POST("/users/:userid/reset-password", RequireAdmin(userStorer), func(c *gin.Context) {
+		userid := c.Param("userid")
+
+		user, err := userStorer.GetUser(userid)
+		if err != nil || user == nil {
+			badReq(c, "errors.invalid_user")
+			return
+		}
+
+		token := generatePasswordResetToken(user, cfg.JWTSecretKey)
+		sendPasswordResetNotification(notifier, user, token)
+
+		c.Status(http.StatusOK)
+	})
+
+	r.DELETE("/users/:userid", RequireAdmin(userStorer), func(c *gin.Context) {
+		userid := c.Param("userid")
+
+		result := cascadeDeleteUser(userid, userStorer, metaStorer, blobStorer, deviceStorer, tokenStore)
+
+		status := http.StatusOK
+		if len(result.Failed) > 0 {
+			status = http.StatusMultiStatus
+		}
+		c.JSON(status, result)
+	})
+}
+
+// cleanupResult reports the outcome of one subsystem's cleanup during a cascading user deletion
+type cleanupResult struct {
+	Subsystem string `json:"subsystem"`
+	Error     string `json:"error"`
+}
+
+// cascadeDeleteResponse summarizes a cascading user deletion so an admin can retry failed parts
+type cascadeDeleteResponse struct {
+	Succeeded []string        `json:"succeeded"`
+	Failed    []cleanupResult `json:"failed"`
+}
+
+// cascadeDeleteUser removes a user and everything they own. Each subsystem is cleaned up
+// concurrently and independently, so a failure in one (e.g. a locked blob file) doesn't
+// prevent the others from being cleaned up.
+func cascadeDeleteUser(userid string, userStorer db.UserStorer, metaStorer db.MetadataStorer, blobStorer db.BlobStorer, deviceStorer db.DeviceStorer, tokenStore db.TokenStore) cascadeDeleteResponse {
+	results := make(chan cleanupResult, 5)
+	var wg sync.WaitGroup
+
+	run := func(subsystem string, cleanup func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := cleanup(); err != nil {
+				log.Errorf("cascade delete: %s cleanup failed for %s: %v", subsystem, userid, err)
+				results <- cleanupResult{Subsystem: subsystem, Error: err.Error()}
+				return
+			}
+			results <- cleanupResult{Subsystem: subsystem}
+		}()
+	}
+
+	run("documents", func() error { return metaStorer.RemoveAllForUser(userid) })
+	run("blobs", func() error { return blobStorer.RemoveAllForUser(userid) })
+	run("devices", func() error { return deviceStorer.RemoveAllForUser(userid) })
+	run("tokens", func() error { return tokenStore.RevokeAllForUser(userid) })
+	run("user", func() error { return userStorer.RemoveUser(userid) })
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	response := cascadeDeleteResponse{}
+	for res := range results {
+		if res.Error == "" {
+			response.Succeeded = append(response.Succeeded, res.Subsystem)
+		} else {
+			response.Failed = append(response.Failed, res)
+		}
+	}
+	return response
+}